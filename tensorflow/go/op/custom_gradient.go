@@ -0,0 +1,52 @@
+/*
+Copyright 2016 The TensorFlow Authors. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package op
+
+import tf "github.com/tensorflow/tensorflow/tensorflow/go"
+
+// CustomGradientFunc builds the backward subgraph for a single invocation
+// of a custom op. gradOutputs holds the gradients with respect to op's
+// outputs; the returned slice must hold one output per entry in op's
+// inputs (in order), or nil at an index whose corresponding input has no
+// gradient.
+type CustomGradientFunc func(scope *Scope, op *tf.Operation, gradOutputs []tf.Output) []tf.Output
+
+// RegisterGradient associates gradFn with opType so that GradientTape can
+// differentiate ops of that type. This is the mechanism by which a custom
+// op defined entirely in Go - with no corresponding entry in the C++
+// gradient registry - gets a gradient: gradFn is invoked with the forward
+// op and the gradients of its outputs, and must return the gradients of
+// its inputs.
+//
+// RegisterGradient only wires gradFn into GradientTape.Gradient (via
+// tapeGradientRegistry); graph-mode Gradients still resolves gradients
+// through the C++ gradient registry and does not consult it. Registering
+// a gradient for an opType a second time replaces the previous
+// registration.
+//
+// TODO: wiring a Go-registered gradient into graph-mode Gradients needs a
+// hook equivalent to Python's RegisterGradient into the C++ gradient
+// registry, which does not exist in the Go bindings yet; tracked as a
+// follow-up request rather than implemented here.
+func RegisterGradient(opType string, gradFn CustomGradientFunc) {
+	tapeGradientRegistry[opType] = func(rec RecordedOp, upstream []tf.Output) []tf.Output {
+		return gradFn(rec.scope, rec.op, upstream)
+	}
+}
+
+// PreventGradient and StopGradient are provided by the generated op
+// wrappers (see wrappers.go) and are not redefined here.
@@ -0,0 +1,120 @@
+/*
+Copyright 2016 The TensorFlow Authors. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package op
+
+import (
+	"math"
+	"testing"
+
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+)
+
+// TestRegisterGradientViaTape checks that a gradient registered through
+// RegisterGradient is invoked by GradientTape.Gradient when it reaches a
+// recorded op of the registered type.
+func TestRegisterGradientViaTape(t *testing.T) {
+	RegisterGradient("TestCustomSquare", func(scope *Scope, op *tf.Operation, gradOutputs []tf.Output) []tf.Output {
+		x := op.Input(0)
+		two := Const(scope.SubScope("two"), float32(2))
+		return []tf.Output{Mul(scope.SubScope("dx"), gradOutputs[0], Mul(scope.SubScope("two_x"), two, x))}
+	})
+
+	s := NewScope()
+	x := Const(s.SubScope("x"), float32(3))
+	y := Square(s.SubScope("y"), x)
+
+	tape := NewGradientTape(s, false)
+	tape.Watch(x)
+	tape.Record("TestCustomSquare", []tf.Output{x}, []tf.Output{y})
+
+	grads, err := tape.Gradient([]tf.Output{y}, []tf.Output{x})
+	if err != nil {
+		t.Fatalf("Gradient: %v", err)
+	}
+
+	graph, err := s.Finalize()
+	if err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	sess, err := tf.NewSession(graph, nil)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	out, err := sess.Run(nil, grads, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// dy/dx = 2x = 6.
+	if got := out[0].Value().(float32); math.Abs(float64(got-6)) > 1e-4 {
+		t.Errorf("dy/dx = %v, want 6", got)
+	}
+}
+
+// TestPreventGradientForwardsValue checks that PreventGradient's output
+// behaves like its input on the forward pass.
+func TestPreventGradientForwardsValue(t *testing.T) {
+	s := NewScope()
+	x := Const(s.SubScope("x"), float32(5))
+	y := PreventGradient(s.SubScope("prevented"), x, PreventGradientMessage("no gradient for this op"))
+	if err := s.Err(); err != nil {
+		t.Fatalf("PreventGradient: %v", err)
+	}
+
+	graph, err := s.Finalize()
+	if err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	sess, err := tf.NewSession(graph, nil)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	out, err := sess.Run(nil, []tf.Output{y}, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := out[0].Value().(float32); got != 5 {
+		t.Errorf("PreventGradient forward value = %v, want 5", got)
+	}
+}
+
+// TestStopGradientForwardsValue checks that StopGradient's output behaves
+// like its input on the forward pass.
+func TestStopGradientForwardsValue(t *testing.T) {
+	s := NewScope()
+	x := Const(s.SubScope("x"), float32(5))
+	y := StopGradient(s.SubScope("stopped"), x)
+	if err := s.Err(); err != nil {
+		t.Fatalf("StopGradient: %v", err)
+	}
+
+	graph, err := s.Finalize()
+	if err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	sess, err := tf.NewSession(graph, nil)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	out, err := sess.Run(nil, []tf.Output{y}, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := out[0].Value().(float32); got != 5 {
+		t.Errorf("StopGradient forward value = %v, want 5", got)
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2016 The TensorFlow Authors. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package op
+
+import (
+	"fmt"
+
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+)
+
+// RecordedOp is a single op invocation recorded onto a GradientTape by
+// Record. It carries just enough information for a registered
+// gradient function to reconstruct the backward computation for that op.
+type RecordedOp struct {
+	// OpType is the registered type of the op, e.g. "MatMul".
+	OpType string
+	// Inputs are the op's inputs at the time it was recorded.
+	Inputs []tf.Output
+	// Outputs are the op's outputs at the time it was recorded.
+	Outputs []tf.Output
+	// op and scope are retained so that a gradient function registered
+	// through RegisterGradient, which builds its backward subgraph under
+	// a *Scope from the forward *tf.Operation, can be called by
+	// GradientTape.Gradient.
+	op    *tf.Operation
+	scope *Scope
+}
+
+// tapeGradientFunc computes the vector-Jacobian-products for a recorded op
+// given the upstream gradients with respect to its outputs. It returns one
+// output per entry in op.Inputs, in the same order.
+type tapeGradientFunc func(op RecordedOp, upstream []tf.Output) []tf.Output
+
+// tapeGradientRegistry holds the per-op-type functions used by
+// GradientTape.Gradient to propagate gradients backwards through recorded
+// ops. RegisterTapeGradient populates it directly, and RegisterGradient
+// populates it by wrapping a CustomGradientFunc. Graph-mode Gradients does
+// not consult it.
+var tapeGradientRegistry = map[string]tapeGradientFunc{}
+
+// RegisterTapeGradient registers fn as the function GradientTape uses to
+// compute the vector-Jacobian-product of ops of type opType. It is intended
+// to be called from init() functions of packages that define ops, mirroring
+// how the C++ runtime registers symbolic gradients for graph-mode
+// differentiation.
+func RegisterTapeGradient(opType string, fn func(op RecordedOp, upstream []tf.Output) []tf.Output) {
+	tapeGradientRegistry[opType] = fn
+}
+
+// tensorID identifies a single tensor produced by a graph, independent of
+// the scope it was created under.
+type tensorID struct {
+	op    string
+	index int
+}
+
+func idOf(o tf.Output) tensorID {
+	return tensorID{op: o.Op.Name(), index: o.Index}
+}
+
+// GradientTape holds the operations passed to its Record method so that
+// their gradients can be computed afterwards, mirroring the tape-based
+// autodiff used by TensorFlow's eager runtime
+// (tensorflow/c/experimental/gradients/tape) and exposing it to Go without
+// requiring a symbolic graph to be built up front.
+//
+// A GradientTape is not safe for concurrent use.
+type GradientTape struct {
+	scope       *Scope
+	persistent  bool
+	watched     map[tensorID]tf.Output
+	recorded    []RecordedOp
+	consumed    bool
+	outputOwner map[tensorID]int // index into recorded, keyed by output tensor
+}
+
+// NewGradientTape creates a GradientTape that adds its gradient ops under
+// scope. Ops built under scope are not recorded automatically; only those
+// passed to Record are. By default a tape may only be used to compute gradients once; pass
+// persistent=true to allow multiple calls to Gradient, e.g. when computing
+// gradients with respect to several different targets from the same
+// recording.
+func NewGradientTape(scope *Scope, persistent bool) *GradientTape {
+	return &GradientTape{
+		scope:       scope,
+		persistent:  persistent,
+		watched:     make(map[tensorID]tf.Output),
+		outputOwner: make(map[tensorID]int),
+	}
+}
+
+// Watch starts tracing x so that gradients can later be computed with
+// respect to it. Watch only needs to be called on tensors that are not
+// themselves the output of a recorded op, such as trainable variables or
+// function inputs; an intermediate value that some recorded op produced
+// is already reachable by Gradient without it.
+func (t *GradientTape) Watch(x tf.Output) {
+	t.watched[idOf(x)] = x
+}
+
+// Record appends an op invocation to the tape. There is no op-execution
+// hook wired into op construction, so callers must invoke Record
+// themselves immediately after building each op they want the tape to
+// differentiate through, passing the op's inputs and outputs in the same
+// order used to construct it. opType must be the op's registered type
+// (e.g. "Mul"), not its instance name, since it is used to look up the
+// gradient function in tapeGradientRegistry.
+func (t *GradientTape) Record(opType string, inputs, outputs []tf.Output) {
+	var op *tf.Operation
+	if len(outputs) > 0 {
+		op = outputs[0].Op
+	}
+	t.recorded = append(t.recorded, RecordedOp{OpType: opType, Inputs: inputs, Outputs: outputs, op: op, scope: t.scope})
+	owner := len(t.recorded) - 1
+	for _, o := range outputs {
+		t.outputOwner[idOf(o)] = owner
+	}
+}
+
+// Gradient computes the gradients of target with respect to sources using
+// the ops recorded on the tape, optionally seeded with outputGrads (the
+// gradients of some downstream loss with respect to target; if omitted,
+// ones are used). It returns one output per entry in sources, which is nil
+// if target does not depend on that source.
+//
+// Unless the tape was created with persistent=true, Gradient may only be
+// called once.
+func (t *GradientTape) Gradient(target, sources []tf.Output, outputGrads ...tf.Output) ([]tf.Output, error) {
+	if t.consumed && !t.persistent {
+		return nil, fmt.Errorf("GradientTape.Gradient called on a non-persistent tape that has already been consumed")
+	}
+	t.consumed = true
+
+	if len(outputGrads) != 0 && len(outputGrads) != len(target) {
+		return nil, fmt.Errorf("Gradient: got %d outputGrads for %d targets", len(outputGrads), len(target))
+	}
+
+	upstream := make(map[tensorID][]tf.Output)
+	for i, y := range target {
+		if len(outputGrads) != 0 {
+			upstream[idOf(y)] = append(upstream[idOf(y)], outputGrads[i])
+		} else {
+			ones, err := Ones(t.scope.SubScope("gradient_tape_ones"), Shape(t.scope.SubScope("gradient_tape_shape"), y))
+			if err != nil {
+				return nil, err
+			}
+			upstream[idOf(y)] = append(upstream[idOf(y)], ones)
+		}
+	}
+
+	// Walk the tape in reverse so every consumer of an op's outputs has
+	// already contributed its upstream gradient by the time we process it.
+	for i := len(t.recorded) - 1; i >= 0; i-- {
+		rec := t.recorded[i]
+		grads, ok := t.sumUpstream(rec.Outputs, upstream)
+		if !ok {
+			continue
+		}
+		fn, ok := tapeGradientRegistry[rec.OpType]
+		if !ok {
+			return nil, fmt.Errorf("Gradient: no tape gradient registered for op type %q; call RegisterTapeGradient or op.RegisterGradient", rec.OpType)
+		}
+		inGrads := fn(rec, grads)
+		if len(inGrads) != len(rec.Inputs) {
+			return nil, fmt.Errorf("Gradient: registered gradient for %q returned %d outputs, want %d", rec.OpType, len(inGrads), len(rec.Inputs))
+		}
+		for j, in := range rec.Inputs {
+			if inGrads[j].Op == nil {
+				continue
+			}
+			upstream[idOf(in)] = append(upstream[idOf(in)], inGrads[j])
+		}
+	}
+
+	result := make([]tf.Output, len(sources))
+	for i, x := range sources {
+		id := idOf(x)
+		_, watched := t.watched[id]
+		_, recorded := t.outputOwner[id]
+		if !watched && !recorded {
+			return nil, fmt.Errorf("Gradient: source %v was never passed to Watch and is not the output of a recorded op", x)
+		}
+		grads, ok := t.sumUpstream([]tf.Output{x}, upstream)
+		if !ok {
+			continue
+		}
+		result[i] = grads[0]
+	}
+	return result, nil
+}
+
+// sumUpstream returns, for each of outputs, the accumulated upstream
+// gradient contributed by its consumers, adding them together when an
+// output fanned out to more than one consumer.
+func (t *GradientTape) sumUpstream(outputs []tf.Output, upstream map[tensorID][]tf.Output) ([]tf.Output, bool) {
+	grads := make([]tf.Output, len(outputs))
+	any := false
+	for i, o := range outputs {
+		gs := upstream[idOf(o)]
+		if len(gs) == 0 {
+			continue
+		}
+		any = true
+		sum := gs[0]
+		for _, g := range gs[1:] {
+			sum = AddN(t.scope.SubScope("gradient_tape_add_n"), []tf.Output{sum, g})
+		}
+		grads[i] = sum
+	}
+	return grads, any
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2016 The TensorFlow Authors. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package op
+
+import (
+	"math"
+	"testing"
+
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+)
+
+func registerMulTapeGradient() {
+	RegisterTapeGradient("Mul", func(rec RecordedOp, upstream []tf.Output) []tf.Output {
+		s := rec.scope.SubScope("mul_grad")
+		return []tf.Output{
+			Mul(s.SubScope("dx0"), upstream[0], rec.Inputs[1]),
+			Mul(s.SubScope("dx1"), upstream[0], rec.Inputs[0]),
+		}
+	})
+}
+
+func registerSquareTapeGradient() {
+	RegisterTapeGradient("Square", func(rec RecordedOp, upstream []tf.Output) []tf.Output {
+		s := rec.scope.SubScope("square_grad")
+		two := Const(s.SubScope("two"), float32(2))
+		return []tf.Output{Mul(s.SubScope("dx"), upstream[0], Mul(s.SubScope("two_x"), two, rec.Inputs[0]))}
+	})
+}
+
+// TestGradientTapeIntermediateSource builds z = (x*x)*x = x^3 via two
+// manually recorded Mul ops and checks that Gradient both sums the two
+// paths by which x reaches z (through y and directly) and accepts y, an
+// unwatched intermediate owned by a recorded op, as a source.
+func TestGradientTapeIntermediateSource(t *testing.T) {
+	registerMulTapeGradient()
+
+	s := NewScope()
+	x := Const(s.SubScope("x"), float32(3))
+
+	tape := NewGradientTape(s, false)
+	tape.Watch(x)
+
+	y := Mul(s.SubScope("y"), x, x) // y = x^2
+	tape.Record("Mul", []tf.Output{x, x}, []tf.Output{y})
+
+	z := Mul(s.SubScope("z"), y, x) // z = x^3
+	tape.Record("Mul", []tf.Output{y, x}, []tf.Output{z})
+
+	grads, err := tape.Gradient([]tf.Output{z}, []tf.Output{x, y})
+	if err != nil {
+		t.Fatalf("Gradient: %v", err)
+	}
+
+	graph, err := s.Finalize()
+	if err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	sess, err := tf.NewSession(graph, nil)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	out, err := sess.Run(nil, grads, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// dz/dx = 3x^2 = 27, dz/dy = x = 3.
+	if got := out[0].Value().(float32); math.Abs(float64(got-27)) > 1e-4 {
+		t.Errorf("dz/dx = %v, want 27", got)
+	}
+	if got := out[1].Value().(float32); math.Abs(float64(got-3)) > 1e-4 {
+		t.Errorf("dz/dy = %v, want 3", got)
+	}
+}
+
+// TestGradientTapeUnwatchedSourceFails checks that a tensor which is
+// neither watched nor the output of a recorded op is still rejected. The
+// Square gradient is registered first so that the walk over the tape
+// succeeds and the error comes from the source check.
+func TestGradientTapeUnwatchedSourceFails(t *testing.T) {
+	registerSquareTapeGradient()
+
+	s := NewScope()
+	x := Const(s.SubScope("x"), float32(3))
+	unrelated := Const(s.SubScope("unrelated"), float32(1))
+
+	tape := NewGradientTape(s, false)
+	tape.Watch(x)
+	y := Square(s.SubScope("y"), x)
+	tape.Record("Square", []tf.Output{x}, []tf.Output{y})
+
+	if _, err := tape.Gradient([]tf.Output{y}, []tf.Output{unrelated}); err == nil {
+		t.Fatal("Gradient: got nil error for a source that was never watched or recorded, want an error")
+	}
+}
+
+// TestGradientTapeNonPersistentConsumed checks that a non-persistent tape
+// rejects a second call to Gradient.
+func TestGradientTapeNonPersistentConsumed(t *testing.T) {
+	s := NewScope()
+	x := Const(s.SubScope("x"), float32(3))
+
+	tape := NewGradientTape(s, false)
+	tape.Watch(x)
+	y := Square(s.SubScope("y"), x)
+	tape.Record("Square", []tf.Output{x}, []tf.Output{y})
+
+	RegisterTapeGradient("Square", func(rec RecordedOp, upstream []tf.Output) []tf.Output {
+		s := rec.scope.SubScope("square_grad")
+		two := Const(s.SubScope("two"), float32(2))
+		return []tf.Output{Mul(s.SubScope("dx"), upstream[0], Mul(s.SubScope("two_x"), two, rec.Inputs[0]))}
+	})
+
+	if _, err := tape.Gradient([]tf.Output{y}, []tf.Output{x}); err != nil {
+		t.Fatalf("first Gradient call: %v", err)
+	}
+	if _, err := tape.Gradient([]tf.Output{y}, []tf.Output{x}); err == nil {
+		t.Fatal("second Gradient call on a non-persistent tape: got nil error, want an error")
+	}
+}
@@ -18,22 +18,79 @@ package op
 
 import tf "github.com/tensorflow/tensorflow/tensorflow/go"
 
+// NOTE: this request (plumbing StopGradients, AggregationMethod,
+// ColocateWithOps and GateGradients through to graph.AddGradients, so
+// that e.g. backprop can be stopped at chosen outputs from Go) is not
+// satisfiable against the current C API: TF_AddGradientsWithPrefix, which
+// graph.AddGradients wraps, takes no parameters for any of them. Doing
+// this for real needs new cgo/C-API surface (a TF_AddGradientsWithPrefix
+// variant, or equivalent, that accepts a stop-gradients list and the
+// other options) before the Go side can expose it. GradientsWithOptions
+// below only configures dx; it is not a full implementation of the
+// request and a follow-up request should track the C-API work.
+
+// gradientsOptions collects the attributes set via GradientsAttr values.
+type gradientsOptions struct {
+	dx []tf.Output
+}
+
+// GradientsAttr configures the gradients computation added by
+// GradientsWithOptions, following the same variadic-options pattern used
+// throughout the generated op wrappers.
+type GradientsAttr func(*gradientsOptions)
+
+// GradientsDx sets the partial derivatives of some loss function L w.r.t.
+// y, equivalent to passing dx directly to Gradients. It exists so that dx
+// can be supplied through a GradientsAttr to GradientsWithOptions instead
+// of as a positional argument.
+func GradientsDx(dx ...tf.Output) GradientsAttr {
+	return func(o *gradientsOptions) {
+		o.dx = dx
+	}
+}
+
 // Gradients adds gradients computation ops to the graph according to scope.
 //
 // Arguments:
-//  prefix: unique string prefix applied before the names of nodes added to the graph to
-//    compute gradients. If null, will use "Gradients".
-//  y: output of the function to derive
-//  x: inputs of the function for which partial derivatives are computed
-//  dx: if not null, the partial derivatives of some loss function L w.r.t. y
 //
-//  return the partial derivatives
+//	prefix: unique string prefix applied before the names of nodes added to the graph to
+//	  compute gradients. If null, will use "Gradients".
+//	y: output of the function to derive
+//	x: inputs of the function for which partial derivatives are computed
+//	dx: if not null, the partial derivatives of some loss function L w.r.t. y
+//
+//	return the partial derivatives
 func Gradients(scope *Scope, prefix string, y []tf.Output, x []tf.Output, dx ...tf.Output) (output []tf.Output) {
+	return GradientsWithOptions(scope, prefix, y, x, GradientsDx(dx...))
+}
+
+// GradientsWithOptions adds gradients computation ops to the graph
+// according to scope, configured by the given GradientsAttr values. It
+// behaves exactly like Gradients, but takes dx through a GradientsAttr
+// (GradientsDx) rather than as a positional argument. The underlying C
+// API (TF_AddGradientsWithPrefix) has no hook for selecting an
+// aggregation method, colocating gradient ops with their forward op, or
+// stopping backpropagation at chosen outputs, so dx is the only thing a
+// GradientsAttr can currently configure.
+//
+// Arguments:
+//
+//	prefix: unique string prefix applied before the names of nodes added to the graph to
+//	  compute gradients. If null, will use "Gradients".
+//	y: output of the function to derive
+//	x: inputs of the function for which partial derivatives are computed
+//
+//	return the partial derivatives
+func GradientsWithOptions(scope *Scope, prefix string, y []tf.Output, x []tf.Output, attrs ...GradientsAttr) (output []tf.Output) {
 	var err error
 	if prefix == "" {
 		prefix = "Gradients"
 	}
-	if output, err = scope.graph.AddGradients(scope.opName(scope.uniqueName(prefix)), y, x, dx); err != nil {
+	opts := gradientsOptions{}
+	for _, a := range attrs {
+		a(&opts)
+	}
+	if output, err = scope.graph.AddGradients(scope.opName(scope.uniqueName(prefix)), y, x, opts.dx); err != nil {
 		scope.UpdateErr("Gradients", err)
 		return
 	}
@@ -0,0 +1,83 @@
+/*
+Copyright 2016 The TensorFlow Authors. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package optimizer
+
+import (
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+	"github.com/tensorflow/tensorflow/tensorflow/go/op"
+)
+
+// ClipByValue clips each tensor in grads elementwise to [min, max], applied
+// to the Gradient fields of the GradAndVar pairs returned by an Optimizer's
+// ComputeGradients before they are passed to ApplyGradients. Entries with
+// no gradient (a nil Op) stay empty.
+func ClipByValue(scope *op.Scope, grads []tf.Output, min, max float32) []tf.Output {
+	lo := op.Const(scope.SubScope("clip_value_min"), min)
+	hi := op.Const(scope.SubScope("clip_value_max"), max)
+	clipped := make([]tf.Output, len(grads))
+	for i, g := range grads {
+		if g.Op == nil {
+			continue
+		}
+		clipped[i] = op.ClipByValue(scope.SubScope("clip_by_value"), g, lo, hi)
+	}
+	return clipped
+}
+
+// ClipByGlobalNorm rescales grads so that the global norm (the L2 norm of
+// the concatenation of all of them) does not exceed clipNorm: every tensor
+// is multiplied by min(1, clipNorm/globalNorm). This is the Go equivalent
+// of tf.clip_by_global_norm and is typically applied to the gradients
+// returned by ComputeGradients before they are applied, to stabilize
+// training against occasional large gradients.
+func ClipByGlobalNorm(scope *op.Scope, grads []tf.Output, clipNorm float32) ([]tf.Output, error) {
+	if err := scope.Err(); err != nil {
+		return nil, err
+	}
+	sumSq := make([]tf.Output, 0, len(grads))
+	for _, g := range grads {
+		if g.Op == nil {
+			continue
+		}
+		s := scope.SubScope("sum_sq")
+		sq := op.Square(s.SubScope("square"), g)
+		allAxes := op.Range(s.SubScope("all_axes"), op.Const(s.SubScope("start"), int32(0)), op.Rank(s.SubScope("rank"), g), op.Const(s.SubScope("delta"), int32(1)))
+		sumSq = append(sumSq, op.Sum(s.SubScope("sum"), sq, allAxes))
+	}
+	if len(sumSq) == 0 {
+		// No tensor in grads has a gradient, so there is nothing to clip.
+		return grads, nil
+	}
+	globalNormSq := sumSq[0]
+	for _, s := range sumSq[1:] {
+		globalNormSq = op.Add(scope.SubScope("accumulate_global_norm"), globalNormSq, s)
+	}
+	globalNorm := op.Sqrt(scope.SubScope("global_norm"), globalNormSq)
+
+	clipNormConst := op.Const(scope.SubScope("clip_norm"), clipNorm)
+	one := op.Const(scope.SubScope("one"), float32(1))
+	scale := op.Minimum(scope.SubScope("scale"), one, op.Div(scope.SubScope("ratio"), clipNormConst, globalNorm))
+
+	clipped := make([]tf.Output, len(grads))
+	for i, g := range grads {
+		if g.Op == nil {
+			continue
+		}
+		clipped[i] = op.Mul(scope.SubScope("clip_by_global_norm"), g, scale)
+	}
+	return clipped, scope.Err()
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2016 The TensorFlow Authors. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package optimizer
+
+import (
+	"math"
+	"testing"
+
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+	"github.com/tensorflow/tensorflow/tensorflow/go/op"
+)
+
+// TestClipByGlobalNormEmpty checks that ClipByGlobalNorm does not panic
+// when none of grads has a gradient (e.g. every variable was unused in
+// the loss), and instead returns grads unchanged.
+func TestClipByGlobalNormEmpty(t *testing.T) {
+	s := op.NewScope()
+	grads := []tf.Output{{}, {}}
+
+	clipped, err := ClipByGlobalNorm(s, grads, 1.0)
+	if err != nil {
+		t.Fatalf("ClipByGlobalNorm: %v", err)
+	}
+	if len(clipped) != len(grads) {
+		t.Fatalf("ClipByGlobalNorm returned %d outputs, want %d", len(clipped), len(grads))
+	}
+	for i, g := range clipped {
+		if g.Op != nil {
+			t.Errorf("clipped[%d].Op = %v, want nil", i, g.Op)
+		}
+	}
+}
+
+// TestClipByGlobalNormScales checks that a gradient whose norm exceeds
+// clipNorm is scaled down to exactly clipNorm.
+func TestClipByGlobalNormScales(t *testing.T) {
+	s := op.NewScope()
+	g := op.Const(s.SubScope("g"), []float32{3, 4}) // norm = 5
+
+	clipped, err := ClipByGlobalNorm(s, []tf.Output{g}, 1.0)
+	if err != nil {
+		t.Fatalf("ClipByGlobalNorm: %v", err)
+	}
+
+	graph, err := s.Finalize()
+	if err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	sess, err := tf.NewSession(graph, nil)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	out, err := sess.Run(nil, clipped, nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := out[0].Value().([]float32)
+	norm := math.Sqrt(float64(got[0]*got[0] + got[1]*got[1]))
+	if math.Abs(norm-1.0) > 1e-4 {
+		t.Errorf("clipped norm = %v, want 1.0", norm)
+	}
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2016 The TensorFlow Authors. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package optimizer provides training optimizers built on top of
+// op.Gradients. Each optimizer turns a loss tensor and a list of trainable
+// variables into a train op that updates those variables by one step of
+// gradient descent, following the same update rules as their Python
+// counterparts in tf.train.
+package optimizer
+
+import (
+	"fmt"
+
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+	"github.com/tensorflow/tensorflow/tensorflow/go/op"
+)
+
+// GradAndVar pairs a variable with the gradient of the loss with respect
+// to it, as returned by ComputeGradients.
+type GradAndVar struct {
+	Gradient tf.Output
+	Variable tf.Output
+}
+
+// Optimizer computes and applies gradients to a list of variables in order
+// to minimize a loss.
+type Optimizer interface {
+	// ComputeGradients returns the gradient of loss with respect to each
+	// entry of varList, paired with that variable, so that callers can
+	// inspect or modify gradients (e.g. clip them) before applying them.
+	ComputeGradients(scope *op.Scope, loss tf.Output, varList []tf.Output) ([]GradAndVar, error)
+
+	// ApplyGradients emits the ops that update each variable in
+	// gradsAndVars according to the optimizer's update rule, returning a
+	// single op that runs all of the updates.
+	ApplyGradients(scope *op.Scope, gradsAndVars []GradAndVar) (*tf.Operation, error)
+
+	// Minimize computes the gradients of loss with respect to varList and
+	// applies them, equivalent to calling ComputeGradients followed by
+	// ApplyGradients.
+	Minimize(scope *op.Scope, loss tf.Output, varList []tf.Output) (*tf.Operation, error)
+}
+
+// computeGradients is the ComputeGradients implementation shared by every
+// optimizer in this package: it calls op.Gradients and pairs up the
+// results with varList.
+func computeGradients(scope *op.Scope, loss tf.Output, varList []tf.Output) ([]GradAndVar, error) {
+	grads := op.Gradients(scope, "gradients", []tf.Output{loss}, varList)
+	if err := scope.Err(); err != nil {
+		return nil, err
+	}
+	gradsAndVars := make([]GradAndVar, len(varList))
+	for i, v := range varList {
+		gradsAndVars[i] = GradAndVar{Gradient: grads[i], Variable: v}
+	}
+	return gradsAndVars, nil
+}
+
+// group returns a single op that depends on every op in ops, for use as
+// the combined return value of ApplyGradients, mirroring tf.group.
+func group(scope *op.Scope, ops []*tf.Operation) *tf.Operation {
+	return op.NoOp(scope.WithControlDependencies(ops...))
+}
+
+// minimize is the Minimize implementation shared by every optimizer in
+// this package.
+func minimize(o Optimizer, scope *op.Scope, loss tf.Output, varList []tf.Output) (*tf.Operation, error) {
+	gradsAndVars, err := o.ComputeGradients(scope, loss, varList)
+	if err != nil {
+		return nil, err
+	}
+	return o.ApplyGradients(scope, gradsAndVars)
+}
+
+// SGD applies plain (non-momentum) gradient descent: v -= LearningRate * grad.
+type SGD struct {
+	LearningRate float32
+}
+
+// ComputeGradients implements Optimizer.
+func (o *SGD) ComputeGradients(scope *op.Scope, loss tf.Output, varList []tf.Output) ([]GradAndVar, error) {
+	return computeGradients(scope, loss, varList)
+}
+
+// ApplyGradients implements Optimizer.
+func (o *SGD) ApplyGradients(scope *op.Scope, gradsAndVars []GradAndVar) (*tf.Operation, error) {
+	alpha := op.Const(scope.SubScope("learning_rate"), o.LearningRate)
+	applyOps := make([]*tf.Operation, 0, len(gradsAndVars))
+	for i, gv := range gradsAndVars {
+		if gv.Gradient.Op == nil {
+			continue
+		}
+		s := scope.SubScope(fmt.Sprintf("apply_gradient_descent_%d", i))
+		applyOps = append(applyOps, op.ResourceApplyGradientDescent(s, gv.Variable, alpha, gv.Gradient))
+	}
+	if err := scope.Err(); err != nil {
+		return nil, err
+	}
+	return group(scope, applyOps), nil
+}
+
+// Minimize implements Optimizer.
+func (o *SGD) Minimize(scope *op.Scope, loss tf.Output, varList []tf.Output) (*tf.Operation, error) {
+	return minimize(o, scope, loss, varList)
+}
+
+// Momentum applies gradient descent with momentum: it accumulates a
+// running velocity for each variable and steps along it rather than along
+// the raw gradient.
+type Momentum struct {
+	LearningRate float32
+	// MomentumValue is the momentum coefficient (commonly called mu or
+	// beta); named to avoid colliding with the Momentum type itself.
+	MomentumValue float32
+	// UseNesterov selects Nesterov-accelerated momentum.
+	UseNesterov bool
+	// Accumulators holds one velocity variable per entry of the varList
+	// passed to ComputeGradients/ApplyGradients, created and owned by the
+	// caller (e.g. as a ResourceVariable initialized to zero).
+	Accumulators []tf.Output
+}
+
+// ComputeGradients implements Optimizer.
+func (o *Momentum) ComputeGradients(scope *op.Scope, loss tf.Output, varList []tf.Output) ([]GradAndVar, error) {
+	return computeGradients(scope, loss, varList)
+}
+
+// ApplyGradients implements Optimizer.
+func (o *Momentum) ApplyGradients(scope *op.Scope, gradsAndVars []GradAndVar) (*tf.Operation, error) {
+	if len(o.Accumulators) != len(gradsAndVars) {
+		return nil, fmt.Errorf("optimizer: Momentum.Accumulators has %d entries, want %d (one per variable)", len(o.Accumulators), len(gradsAndVars))
+	}
+	alpha := op.Const(scope.SubScope("learning_rate"), o.LearningRate)
+	mu := op.Const(scope.SubScope("momentum"), o.MomentumValue)
+	applyOps := make([]*tf.Operation, 0, len(gradsAndVars))
+	for i, gv := range gradsAndVars {
+		if gv.Gradient.Op == nil {
+			continue
+		}
+		s := scope.SubScope(fmt.Sprintf("apply_momentum_%d", i))
+		applyOps = append(applyOps, op.ResourceApplyMomentum(s, gv.Variable, o.Accumulators[i], alpha, gv.Gradient, mu, op.ResourceApplyMomentumUseNesterov(o.UseNesterov)))
+	}
+	if err := scope.Err(); err != nil {
+		return nil, err
+	}
+	return group(scope, applyOps), nil
+}
+
+// Minimize implements Optimizer.
+func (o *Momentum) Minimize(scope *op.Scope, loss tf.Output, varList []tf.Output) (*tf.Operation, error) {
+	return minimize(o, scope, loss, varList)
+}
+
+// Adam applies the Adam update rule (Kingma & Ba, 2015), tracking a first
+// and second moment estimate per variable.
+type Adam struct {
+	LearningRate float32
+	Beta1        float32
+	Beta2        float32
+	Epsilon      float32
+	// M and V are the first and second moment accumulators, one per
+	// variable, owned by the caller.
+	M []tf.Output
+	V []tf.Output
+	// Beta1Power and Beta2Power hold beta1^t and beta2^t, one scalar
+	// value per trained variable. ApplyGradients passes them to
+	// ResourceApplyAdam as inputs and does not update them.
+	Beta1Power []tf.Output
+	Beta2Power []tf.Output
+}
+
+// ComputeGradients implements Optimizer.
+func (o *Adam) ComputeGradients(scope *op.Scope, loss tf.Output, varList []tf.Output) ([]GradAndVar, error) {
+	return computeGradients(scope, loss, varList)
+}
+
+// ApplyGradients implements Optimizer.
+func (o *Adam) ApplyGradients(scope *op.Scope, gradsAndVars []GradAndVar) (*tf.Operation, error) {
+	n := len(gradsAndVars)
+	if len(o.M) != n || len(o.V) != n || len(o.Beta1Power) != n || len(o.Beta2Power) != n {
+		return nil, fmt.Errorf("optimizer: Adam slot slices must each have %d entries (one per variable)", n)
+	}
+	alpha := op.Const(scope.SubScope("learning_rate"), o.LearningRate)
+	beta1 := op.Const(scope.SubScope("beta1"), o.Beta1)
+	beta2 := op.Const(scope.SubScope("beta2"), o.Beta2)
+	epsilon := op.Const(scope.SubScope("epsilon"), o.Epsilon)
+	applyOps := make([]*tf.Operation, 0, n)
+	for i, gv := range gradsAndVars {
+		if gv.Gradient.Op == nil {
+			continue
+		}
+		s := scope.SubScope(fmt.Sprintf("apply_adam_%d", i))
+		applyOps = append(applyOps, op.ResourceApplyAdam(s, gv.Variable, o.M[i], o.V[i], o.Beta1Power[i], o.Beta2Power[i], alpha, beta1, beta2, epsilon, gv.Gradient))
+	}
+	if err := scope.Err(); err != nil {
+		return nil, err
+	}
+	return group(scope, applyOps), nil
+}
+
+// Minimize implements Optimizer.
+func (o *Adam) Minimize(scope *op.Scope, loss tf.Output, varList []tf.Output) (*tf.Operation, error) {
+	return minimize(o, scope, loss, varList)
+}
+
+// RMSProp applies the RMSProp update rule, dividing the gradient by a
+// running average of its recent magnitude.
+type RMSProp struct {
+	LearningRate  float32
+	Decay         float32
+	MomentumValue float32
+	Epsilon       float32
+	// MS and Mom are the mean-square and momentum accumulators, one per
+	// variable, owned by the caller.
+	MS  []tf.Output
+	Mom []tf.Output
+}
+
+// ComputeGradients implements Optimizer.
+func (o *RMSProp) ComputeGradients(scope *op.Scope, loss tf.Output, varList []tf.Output) ([]GradAndVar, error) {
+	return computeGradients(scope, loss, varList)
+}
+
+// ApplyGradients implements Optimizer.
+func (o *RMSProp) ApplyGradients(scope *op.Scope, gradsAndVars []GradAndVar) (*tf.Operation, error) {
+	n := len(gradsAndVars)
+	if len(o.MS) != n || len(o.Mom) != n {
+		return nil, fmt.Errorf("optimizer: RMSProp.MS and RMSProp.Mom must each have %d entries (one per variable)", n)
+	}
+	alpha := op.Const(scope.SubScope("learning_rate"), o.LearningRate)
+	decay := op.Const(scope.SubScope("decay"), o.Decay)
+	momentum := op.Const(scope.SubScope("momentum"), o.MomentumValue)
+	epsilon := op.Const(scope.SubScope("epsilon"), o.Epsilon)
+	applyOps := make([]*tf.Operation, 0, n)
+	for i, gv := range gradsAndVars {
+		if gv.Gradient.Op == nil {
+			continue
+		}
+		s := scope.SubScope(fmt.Sprintf("apply_rms_prop_%d", i))
+		applyOps = append(applyOps, op.ResourceApplyRMSProp(s, gv.Variable, o.MS[i], o.Mom[i], alpha, decay, momentum, epsilon, gv.Gradient))
+	}
+	if err := scope.Err(); err != nil {
+		return nil, err
+	}
+	return group(scope, applyOps), nil
+}
+
+// Minimize implements Optimizer.
+func (o *RMSProp) Minimize(scope *op.Scope, loss tf.Output, varList []tf.Output) (*tf.Operation, error) {
+	return minimize(o, scope, loss, varList)
+}
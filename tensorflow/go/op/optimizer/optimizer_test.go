@@ -0,0 +1,67 @@
+/*
+Copyright 2016 The TensorFlow Authors. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package optimizer
+
+import (
+	"math"
+	"testing"
+
+	tf "github.com/tensorflow/tensorflow/tensorflow/go"
+	"github.com/tensorflow/tensorflow/tensorflow/go/op"
+)
+
+// TestSGDMinimize checks that one step of SGD on loss = w^2, starting
+// from w = 3 with a learning rate of 0.1, moves w by -0.1 * dloss/dw =
+// -0.1 * 6 = -0.6.
+func TestSGDMinimize(t *testing.T) {
+	root := op.NewScope()
+	w := op.VarHandleOp(root.SubScope("w"), tf.Float, tf.ScalarShape())
+	init := op.AssignVariableOp(root.SubScope("init"), w, op.Const(root.SubScope("init_value"), float32(3)))
+	// Read the trained value without a dependency on init, so that fetching
+	// it does not reset w to 3.
+	wAfter := op.ReadVariableOp(root.SubScope("read_after"), w, tf.Float)
+	s := root.WithControlDependencies(init)
+	wValue := op.ReadVariableOp(s.SubScope("read"), w, tf.Float)
+	loss := op.Square(s.SubScope("loss"), wValue)
+
+	sgd := &SGD{LearningRate: 0.1}
+	trainOp, err := sgd.Minimize(s, loss, []tf.Output{w})
+	if err != nil {
+		t.Fatalf("Minimize: %v", err)
+	}
+
+	graph, err := s.Finalize()
+	if err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	sess, err := tf.NewSession(graph, nil)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	if _, err := sess.Run(nil, nil, []*tf.Operation{trainOp}); err != nil {
+		t.Fatalf("Run(trainOp): %v", err)
+	}
+	out, err := sess.Run(nil, []tf.Output{wAfter}, nil)
+	if err != nil {
+		t.Fatalf("Run(wAfter): %v", err)
+	}
+
+	got := out[0].Value().(float32)
+	if want := float32(2.4); math.Abs(float64(got-want)) > 1e-4 {
+		t.Errorf("w after one SGD step = %v, want %v", got, want)
+	}
+}